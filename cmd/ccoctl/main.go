@@ -6,7 +6,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/openshift/cloud-credential-operator/pkg/cmd/provisioning/aws"
+	"github.com/openshift/cloud-credential-operator/pkg/cmd/provisioning/azure"
 	"github.com/openshift/cloud-credential-operator/pkg/cmd/provisioning/ibmcloud"
+	"github.com/openshift/cloud-credential-operator/pkg/cmd/provisioning/openstack"
 )
 
 func main() {
@@ -16,7 +18,9 @@ func main() {
 	}
 
 	rootCmd.AddCommand(aws.NewAWSCmd())
+	rootCmd.AddCommand(azure.NewAzureCmd())
 	rootCmd.AddCommand(ibmcloud.NewIBMCloudCmd())
+	rootCmd.AddCommand(openstack.NewOpenStackCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal(err)
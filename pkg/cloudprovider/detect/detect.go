@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package detect gives downstream operators (Loki, ARO, Lightspeed, and
+// others) a single, versioned API for discovering which CredentialsMode
+// the cloud-credential-operator is running in, and what that mode
+// actually offers on this cluster, instead of each one reimplementing its
+// own probe of CloudCredential/Secret/Infrastructure objects.
+//
+// Today only the OpenStack secretannotator calls PublishCapabilities, and
+// it always reports SupportsSTS=false (Keystone Application Credentials
+// aren't a federated-token flow). AWS/Azure/GCP/IBM have no actuator in
+// this tree that publishes yet, so DetectMode falls back to the
+// cluster-wide Authentication.Spec.ServiceAccountIssuer signal for those
+// platforms' SupportsSTS/OIDCIssuerURL/TokenPath; RootSecretAnnotated is
+// only ever true once a platform's own actuator starts publishing.
+package detect
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+const (
+	// CapabilitiesConfigMapNamespace/Name hold the singleton ConfigMap the
+	// operator's per-provider actuators populate with the capabilities
+	// DetectMode reports, so that consumers never need RBAC to read the
+	// root credentials Secret directly.
+	CapabilitiesConfigMapNamespace = "openshift-cloud-credential-operator"
+	CapabilitiesConfigMapName      = "cloud-credential-operator-capabilities"
+
+	operatorConfigName       = "cluster"
+	authenticationConfigName = "cluster"
+
+	dataKeySupportsSTS         = "supports-sts"
+	dataKeyOIDCIssuerURL       = "oidc-issuer-url"
+	dataKeyTokenPath           = "token-path"
+	dataKeyRootSecretAnnotated = "root-secret-annotated"
+
+	// projectedTokenPath is the in-pod path of the bound ServiceAccount
+	// token OpenShift's pod admission webhook projects into every
+	// workload, and what AWS STS/Azure/GCP workload identity federation
+	// consumers read when SupportsSTS is true.
+	projectedTokenPath = "/var/run/secrets/openshift/serviceaccount/token"
+)
+
+// ProviderCapabilities describes what the active CredentialsMode actually
+// offers on this cluster. Zero values mean "unknown" or "not applicable",
+// not "false" - callers should branch on Mode first.
+type ProviderCapabilities struct {
+	// SupportsSTS is true when the active cloud provider is configured for
+	// short-lived, workload-scoped credentials (AWS STS, Azure workload
+	// identity federation, GCP workload identity federation).
+	SupportsSTS bool
+
+	// OIDCIssuerURL is the OIDC issuer consumers should federate against
+	// when SupportsSTS is true. Empty when not applicable.
+	OIDCIssuerURL string
+
+	// TokenPath is the in-pod path a projected ServiceAccount token can be
+	// read from when SupportsSTS is true. Empty when not applicable.
+	TokenPath string
+
+	// RootSecretAnnotated reports whether the operator has finished
+	// stamping the root credentials Secret with its
+	// cloudcredential.openshift.io/mode annotation. Consumers can use this
+	// to distinguish "not yet reconciled" from "genuinely no capabilities".
+	RootSecretAnnotated bool
+}
+
+// DetectMode returns the cluster's configured CredentialsMode along with
+// the ProviderCapabilities this cluster offers. It never returns an error
+// for "CCO isn't configured yet" - that's reported as
+// CloudCredentialsMode("") with zero-value capabilities.
+func DetectMode(ctx context.Context, c client.Client) (operatorv1.CloudCredentialsMode, ProviderCapabilities, error) {
+	cloudCredConfig := &operatorv1.CloudCredential{}
+	mode := operatorv1.CloudCredentialsMode("")
+	if err := c.Get(ctx, types.NamespacedName{Name: operatorConfigName}, cloudCredConfig); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", ProviderCapabilities{}, fmt.Errorf("failed to fetch CloudCredential/%s: %w", operatorConfigName, err)
+		}
+	} else {
+		mode = cloudCredConfig.Spec.CredentialsMode
+	}
+
+	capabilities, published, err := getCapabilities(ctx, c)
+	if err != nil {
+		return "", ProviderCapabilities{}, err
+	}
+
+	// Only fall back to the cluster-wide Authentication signal when no
+	// platform actuator has published capabilities at all: once an
+	// actuator has published, including an explicit SupportsSTS=false for
+	// a platform with no federated-credential flow, that's authoritative
+	// and must not be overridden.
+	if !published {
+		capabilities, err = stsCapabilitiesFromAuthentication(ctx, c)
+		if err != nil {
+			return "", ProviderCapabilities{}, err
+		}
+	}
+
+	return mode, capabilities, nil
+}
+
+// stsCapabilitiesFromAuthentication derives SupportsSTS/OIDCIssuerURL from
+// the cluster-wide Authentication config, the same signal AWS STS and
+// Azure/GCP workload identity federation all key off of regardless of
+// platform: a non-empty ServiceAccountIssuer means the cluster was
+// installed (or reconfigured) for short-lived, federated credentials. This
+// lets DetectMode report SupportsSTS correctly even for platforms whose
+// actuator doesn't call PublishCapabilities.
+func stsCapabilitiesFromAuthentication(ctx context.Context, c client.Client) (ProviderCapabilities, error) {
+	auth := &configv1.Authentication{}
+	if err := c.Get(ctx, types.NamespacedName{Name: authenticationConfigName}, auth); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ProviderCapabilities{}, nil
+		}
+		return ProviderCapabilities{}, fmt.Errorf("failed to fetch Authentication/%s: %w", authenticationConfigName, err)
+	}
+
+	if auth.Spec.ServiceAccountIssuer == "" {
+		return ProviderCapabilities{}, nil
+	}
+
+	return ProviderCapabilities{
+		SupportsSTS:   true,
+		OIDCIssuerURL: auth.Spec.ServiceAccountIssuer,
+		TokenPath:     projectedTokenPath,
+	}, nil
+}
+
+// getCapabilities reads the singleton capabilities ConfigMap published by
+// the active per-provider actuator. A missing ConfigMap means no actuator
+// has published yet (or the active one never does), reported via the
+// second return value so DetectMode knows when it's safe to fall back to
+// the cluster-wide Authentication signal instead.
+func getCapabilities(ctx context.Context, c client.Client) (ProviderCapabilities, bool, error) {
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: CapabilitiesConfigMapNamespace, Name: CapabilitiesConfigMapName}, cm)
+	if apierrors.IsNotFound(err) {
+		return ProviderCapabilities{}, false, nil
+	}
+	if err != nil {
+		return ProviderCapabilities{}, false, fmt.Errorf("failed to fetch ConfigMap/%s: %w", CapabilitiesConfigMapName, err)
+	}
+
+	return ProviderCapabilities{
+		SupportsSTS:         cm.Data[dataKeySupportsSTS] == "true",
+		OIDCIssuerURL:       cm.Data[dataKeyOIDCIssuerURL],
+		TokenPath:           cm.Data[dataKeyTokenPath],
+		RootSecretAnnotated: cm.Data[dataKeyRootSecretAnnotated] == "true",
+	}, true, nil
+}
+
+// PublishCapabilities is called by each per-provider actuator after it
+// reconciles, to keep the singleton capabilities ConfigMap DetectMode
+// reads up to date. It creates the ConfigMap on first use.
+func PublishCapabilities(ctx context.Context, c client.Client, capabilities ProviderCapabilities) error {
+	data := map[string]string{
+		dataKeySupportsSTS:         boolString(capabilities.SupportsSTS),
+		dataKeyOIDCIssuerURL:       capabilities.OIDCIssuerURL,
+		dataKeyTokenPath:           capabilities.TokenPath,
+		dataKeyRootSecretAnnotated: boolString(capabilities.RootSecretAnnotated),
+	}
+
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: CapabilitiesConfigMapNamespace, Name: CapabilitiesConfigMapName}
+	err := c.Get(ctx, key, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+			},
+			Data: data,
+		}
+		return c.Create(ctx, cm)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch ConfigMap/%s: %w", CapabilitiesConfigMapName, err)
+	}
+
+	if reflect.DeepEqual(cm.Data, data) {
+		return nil
+	}
+
+	cm.Data = data
+	return c.Update(ctx, cm)
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
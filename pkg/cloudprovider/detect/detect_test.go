@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package detect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	schemeutils "github.com/openshift/cloud-credential-operator/pkg/util"
+)
+
+func TestDetectMode(t *testing.T) {
+	schemeutils.SetupScheme(scheme.Scheme)
+
+	mintConfig := &operatorv1.CloudCredential{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       operatorv1.CloudCredentialSpec{CredentialsMode: operatorv1.CloudCredentialsModeMint},
+	}
+
+	stsCapabilitiesConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CapabilitiesConfigMapName,
+			Namespace: CapabilitiesConfigMapNamespace,
+		},
+		Data: map[string]string{
+			dataKeySupportsSTS:         "true",
+			dataKeyOIDCIssuerURL:       "https://issuer.example.com",
+			dataKeyTokenPath:           "/var/run/secrets/openshift/serviceaccount/token",
+			dataKeyRootSecretAnnotated: "true",
+		},
+	}
+
+	for _, tc := range []struct {
+		name             string
+		existing         []runtime.Object
+		wantMode         operatorv1.CloudCredentialsMode
+		wantCapabilities ProviderCapabilities
+	}{
+		{
+			name:             "no CloudCredential config, no capabilities",
+			existing:         nil,
+			wantMode:         "",
+			wantCapabilities: ProviderCapabilities{},
+		},
+		{
+			name:             "mode configured, no capabilities published yet",
+			existing:         []runtime.Object{mintConfig},
+			wantMode:         operatorv1.CloudCredentialsModeMint,
+			wantCapabilities: ProviderCapabilities{},
+		},
+		{
+			name:     "mode configured, capabilities published",
+			existing: []runtime.Object{mintConfig, stsCapabilitiesConfigMap},
+			wantMode: operatorv1.CloudCredentialsModeMint,
+			wantCapabilities: ProviderCapabilities{
+				SupportsSTS:         true,
+				OIDCIssuerURL:       "https://issuer.example.com",
+				TokenPath:           "/var/run/secrets/openshift/serviceaccount/token",
+				RootSecretAnnotated: true,
+			},
+		},
+		{
+			name: "mode configured, STS only signalled by Authentication",
+			existing: []runtime.Object{mintConfig, &configv1.Authentication{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.AuthenticationSpec{ServiceAccountIssuer: "https://issuer.example.com"},
+			}},
+			wantMode: operatorv1.CloudCredentialsModeMint,
+			wantCapabilities: ProviderCapabilities{
+				SupportsSTS:   true,
+				OIDCIssuerURL: "https://issuer.example.com",
+				TokenPath:     projectedTokenPath,
+			},
+		},
+		{
+			name: "actuator explicitly published no STS support, Authentication must not override it",
+			existing: []runtime.Object{mintConfig,
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      CapabilitiesConfigMapName,
+						Namespace: CapabilitiesConfigMapNamespace,
+					},
+					Data: map[string]string{
+						dataKeySupportsSTS:         "false",
+						dataKeyRootSecretAnnotated: "true",
+					},
+				},
+				&configv1.Authentication{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Spec:       configv1.AuthenticationSpec{ServiceAccountIssuer: "https://issuer.example.com"},
+				},
+			},
+			wantMode: operatorv1.CloudCredentialsModeMint,
+			wantCapabilities: ProviderCapabilities{
+				RootSecretAnnotated: true,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := fake.NewFakeClient(tc.existing...)
+
+			mode, capabilities, err := DetectMode(context.TODO(), fakeClient)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantMode, mode)
+			assert.Equal(t, tc.wantCapabilities, capabilities)
+		})
+	}
+}
+
+func TestPublishCapabilities(t *testing.T) {
+	schemeutils.SetupScheme(scheme.Scheme)
+
+	fakeClient := fake.NewFakeClient()
+
+	capabilities := ProviderCapabilities{
+		SupportsSTS:   true,
+		OIDCIssuerURL: "https://issuer.example.com",
+	}
+
+	require.NoError(t, PublishCapabilities(context.TODO(), fakeClient, capabilities))
+
+	_, got, err := DetectMode(context.TODO(), fakeClient)
+	require.NoError(t, err)
+	assert.Equal(t, capabilities, got)
+
+	// Publishing again should update the existing ConfigMap in place
+	// rather than erroring out because it already exists.
+	capabilities.SupportsSTS = false
+	require.NoError(t, PublishCapabilities(context.TODO(), fakeClient, capabilities))
+
+	_, got, err = DetectMode(context.TODO(), fakeClient)
+	require.NoError(t, err)
+	assert.Equal(t, capabilities, got)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure implements the `ccoctl azure` command tree, which
+// provisions Azure AD Workload Identity Federation for OpenShift
+// CredentialsRequests out-of-cluster, mirroring the AWS STS ccoctl flow.
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+const (
+	// federatedTokenFilePath is the path the token projected by the
+	// ServiceAccount issuer is expected to live at inside consuming pods,
+	// matching the path the operator's pod-identity webhook configures.
+	federatedTokenFilePath = "/var/run/secrets/openshift/serviceaccount/token"
+
+	jwksFileName         = "keys.json"
+	discoveryDocFileName = "openid-configuration.json"
+)
+
+// NewAzureCmd implements the "ccoctl azure" subcommand.
+func NewAzureCmd() *cobra.Command {
+	azureCmd := &cobra.Command{
+		Use:   "azure",
+		Short: "Manage credentials objects for Azure Workload Identity",
+		Long:  "Creates/updates/deletes Azure Workload Identity Federation resources for OpenShift CredentialsRequests",
+	}
+
+	azureCmd.AddCommand(NewCreateKeyPairCmd())
+	azureCmd.AddCommand(NewCreateOIDCIssuerCmd())
+	azureCmd.AddCommand(NewCreateManagedIdentitiesCmd())
+	azureCmd.AddCommand(NewCreateAllCmd())
+
+	return azureCmd
+}
+
+// getListOfCredentialsRequests reads every manifest in credRequestDir and
+// returns the CredentialsRequests found in it, skipping any manifest that
+// isn't a CredentialsRequest (the directory is shared with other manifest
+// kinds during a standard OpenShift install).
+func getListOfCredentialsRequests(credRequestDir string) ([]*credreqv1.CredentialsRequest, error) {
+	crs := []*credreqv1.CredentialsRequest{}
+
+	files, err := ioutil.ReadDir(credRequestDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", credRequestDir, err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(credRequestDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", file.Name(), err)
+		}
+
+		cr := &credreqv1.CredentialsRequest{}
+		if err := yaml.Unmarshal(data, cr); err != nil {
+			log.WithField("file", file.Name()).Debug("skipping non-CredentialsRequest manifest")
+			continue
+		}
+
+		if cr.Kind != "CredentialsRequest" {
+			continue
+		}
+
+		if cr.Spec.ProviderSpec == nil {
+			continue
+		}
+
+		crs = append(crs, cr)
+	}
+
+	return crs, nil
+}
+
+// managedIdentityName derives the User Assigned Managed Identity name for
+// a given CredentialsRequest, namespacing it by the cluster name so
+// multiple clusters sharing a resource group don't collide.
+func managedIdentityName(clusterName string, cr *credreqv1.CredentialsRequest) string {
+	return fmt.Sprintf("%s-%s-%s", clusterName, cr.Namespace, cr.Spec.SecretRef.Name)
+}
+
+// writeManifest renders obj as YAML to outputDir/fileName, creating
+// outputDir if necessary. Used throughout the azure subcommand tree for
+// --dry-run output and for target Secret manifests alike.
+func writeManifest(outputDir, fileName string, obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", outputDir, err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, fileName), data, 0600)
+}
+
+// writeJSONDocument renders obj as JSON to outputDir/fileName. Unlike
+// writeManifest, this is used for documents that are served directly over
+// HTTP (the JWKS and OIDC discovery documents), which must be valid JSON
+// rather than YAML.
+func writeJSONDocument(outputDir, fileName string, obj interface{}) error {
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", outputDir, err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, fileName), data, 0644)
+}
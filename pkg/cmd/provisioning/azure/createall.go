@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	// CreateAllOpts captures the options for the create-all command.
+	CreateAllOpts struct {
+		Name              string
+		Region            string
+		SubscriptionID    string
+		TenantID          string
+		ResourceGroupName string
+		CredRequestDir    string
+		OutputDir         string
+		DryRun            bool
+	}
+)
+
+// NewCreateAllCmd runs every step needed to provision Azure Workload
+// Identity Federation out-of-cluster: create-key-pair, create-oidc-issuer,
+// and create-managed-identities, in that order. It mirrors the "create-all"
+// convenience command offered by the AWS ccoctl subcommand.
+func NewCreateAllCmd() *cobra.Command {
+	createAllCmd := &cobra.Command{
+		Use:   "create-all",
+		Short: "Create all the required Azure Workload Identity resources",
+		RunE:  createAllCmd,
+	}
+
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.Name, "name", "", "User-defined name for all created Azure resources (can be separate from the cluster's infra-id)")
+	createAllCmd.MarkPersistentFlagRequired("name")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.Region, "region", "", "Azure region in which to create resources")
+	createAllCmd.MarkPersistentFlagRequired("region")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.SubscriptionID, "subscription-id", "", "Azure subscription ID to create resources in")
+	createAllCmd.MarkPersistentFlagRequired("subscription-id")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.TenantID, "tenant-id", "", "Azure AD tenant ID the managed identities belong to")
+	createAllCmd.MarkPersistentFlagRequired("tenant-id")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.ResourceGroupName, "resource-group-name", "", "Name of an existing Azure resource group to create resources in")
+	createAllCmd.MarkPersistentFlagRequired("resource-group-name")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.CredRequestDir, "credentials-requests-dir", "", "Directory containing files of CredentialsRequests to create managed identities for")
+	createAllCmd.MarkPersistentFlagRequired("credentials-requests-dir")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.OutputDir, "output-dir", ".", "Directory to place generated manifests in")
+	createAllCmd.PersistentFlags().BoolVar(&CreateAllOpts.DryRun, "dry-run", false, "Skip creating cloud objects, and just save the files to disk")
+
+	return createAllCmd
+}
+
+func createAllCmd(cmd *cobra.Command, args []string) error {
+	opts := CreateAllOpts
+
+	if err := createKeyPair(opts.OutputDir); err != nil {
+		return err
+	}
+
+	issuerURL, err := createOIDCIssuer(opts.Name, opts.Region, opts.SubscriptionID, opts.ResourceGroupName, opts.OutputDir, opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	CreateManagedIdentitiesOpts = managedIdentitiesOptions{
+		Name:              opts.Name,
+		Region:            opts.Region,
+		SubscriptionID:    opts.SubscriptionID,
+		TenantID:          opts.TenantID,
+		ResourceGroupName: opts.ResourceGroupName,
+		IssuerURL:         issuerURL,
+		CredRequestDir:    opts.CredRequestDir,
+		OutputDir:         opts.OutputDir,
+		DryRun:            opts.DryRun,
+	}
+
+	return createManagedIdentitiesCmd(cmd, args)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-jose/go-jose/v3"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	privateKeyFileName = "serviceaccount-signer.private"
+	publicKeyFileName  = "serviceaccount-signer.public"
+
+	rsaKeyBits = 4096
+)
+
+var (
+	// CreateKeyPairOpts captures the options for the create-key-pair
+	// command.
+	CreateKeyPairOpts struct {
+		OutputDir string
+	}
+)
+
+// NewCreateKeyPairCmd generates (or reuses) the RSA key pair used to sign
+// ServiceAccount tokens, along with the JWKS document that will be
+// published at the OIDC issuer so Azure AD can validate those tokens.
+func NewCreateKeyPairCmd() *cobra.Command {
+	createKeyPairCmd := &cobra.Command{
+		Use:   "create-key-pair",
+		Short: "Create the RSA keypair and JWKS used for the OIDC issuer",
+		RunE:  createKeyPairCmd,
+	}
+
+	createKeyPairCmd.PersistentFlags().StringVar(&CreateKeyPairOpts.OutputDir, "output-dir", ".", "Directory to place generated key pair and JWKS in")
+
+	return createKeyPairCmd
+}
+
+func createKeyPairCmd(cmd *cobra.Command, args []string) error {
+	return createKeyPair(CreateKeyPairOpts.OutputDir)
+}
+
+// createKeyPair writes a new RSA key pair and its corresponding JWKS
+// document to outputDir/serviceaccount-signer. If a key pair already
+// exists there, it is reused so re-running create-all doesn't invalidate
+// tokens signed against a previously published JWKS.
+func createKeyPair(outputDir string) error {
+	keysDir := filepath.Join(outputDir, "serviceaccount-signer")
+	privateKeyPath := filepath.Join(keysDir, privateKeyFileName+".pem")
+
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		log.WithField("path", privateKeyPath).Info("key pair already exists, reusing")
+		return nil
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return fmt.Errorf("failed to generate RSA key pair: %w", err)
+	}
+
+	if err := os.MkdirAll(keysDir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", keysDir, err)
+	}
+
+	privateKeyBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	if err := ioutil.WriteFile(privateKeyPath, privateKeyBytes, 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})
+	if err := ioutil.WriteFile(filepath.Join(keysDir, publicKeyFileName+".pem"), publicKeyPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	jwks := jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{
+				Key:       &privateKey.PublicKey,
+				KeyID:     "1",
+				Algorithm: "RS256",
+				Use:       "sig",
+			},
+		},
+	}
+
+	return writeJSONDocument(keysDir, jwksFileName, jwks)
+}
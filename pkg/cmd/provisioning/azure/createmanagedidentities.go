@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/msi/armmsi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+// managedIdentitiesOptions captures the options for the
+// create-managed-identities command.
+type managedIdentitiesOptions struct {
+	Name              string
+	Region            string
+	SubscriptionID    string
+	TenantID          string
+	ResourceGroupName string
+	IssuerURL         string
+	CredRequestDir    string
+	OutputDir         string
+	DryRun            bool
+}
+
+var (
+	// CreateManagedIdentitiesOpts captures the options for the
+	// create-managed-identities command.
+	CreateManagedIdentitiesOpts managedIdentitiesOptions
+)
+
+// NewCreateManagedIdentitiesCmd creates a User Assigned Managed Identity
+// per CredentialsRequest, federates it with the OIDC issuer created by
+// create-oidc-issuer for that request's target ServiceAccount, and writes
+// a target-namespace Secret manifest consumers can build a
+// WorkloadIdentityCredential from.
+func NewCreateManagedIdentitiesCmd() *cobra.Command {
+	createManagedIdentitiesCmd := &cobra.Command{
+		Use:   "create-managed-identities",
+		Short: "Create User Assigned Managed Identities for each CredentialsRequest",
+		RunE:  createManagedIdentitiesCmd,
+	}
+
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.Name, "name", "", "User-defined name for all created Azure resources (can be separate from the cluster's infra-id)")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("name")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.Region, "region", "", "Azure region in which to create the managed identities")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("region")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.SubscriptionID, "subscription-id", "", "Azure subscription ID to create resources in")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("subscription-id")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.TenantID, "tenant-id", "", "Azure AD tenant ID the managed identities belong to")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("tenant-id")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.ResourceGroupName, "resource-group-name", "", "Name of an existing Azure resource group to create the managed identities in")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("resource-group-name")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.IssuerURL, "issuer-url", "", "OIDC issuer URL returned by create-oidc-issuer")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("issuer-url")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.CredRequestDir, "credentials-requests-dir", "", "Directory containing files of CredentialsRequests to create managed identities for")
+	createManagedIdentitiesCmd.MarkPersistentFlagRequired("credentials-requests-dir")
+	createManagedIdentitiesCmd.PersistentFlags().StringVar(&CreateManagedIdentitiesOpts.OutputDir, "output-dir", ".", "Directory to place generated manifests in")
+	createManagedIdentitiesCmd.PersistentFlags().BoolVar(&CreateManagedIdentitiesOpts.DryRun, "dry-run", false, "Skip creating objects, and just save the files to disk")
+
+	return createManagedIdentitiesCmd
+}
+
+// targetSecretData is the set of keys written into the target namespace
+// Secret so that consumers can construct an azidentity
+// WorkloadIdentityCredential (or DefaultAzureCredential, which recognizes
+// the same AZURE_* environment variables) without bespoke code per
+// operator.
+type targetSecretData struct {
+	ClientID           string
+	TenantID           string
+	SubscriptionID     string
+	Region             string
+	FederatedTokenFile string
+}
+
+func createManagedIdentitiesCmd(cmd *cobra.Command, args []string) error {
+	opts := CreateManagedIdentitiesOpts
+
+	crs, err := getListOfCredentialsRequests(opts.CredRequestDir)
+	if err != nil {
+		return err
+	}
+
+	var msiClient *armmsi.UserAssignedIdentitiesClient
+	var fedClient *armmsi.FederatedIdentityCredentialsClient
+	if !opts.DryRun {
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return fmt.Errorf("failed to obtain Azure credential: %w", err)
+		}
+		msiClient, err = armmsi.NewUserAssignedIdentitiesClient(opts.SubscriptionID, cred, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create managed identities client: %w", err)
+		}
+		fedClient, err = armmsi.NewFederatedIdentityCredentialsClient(opts.SubscriptionID, cred, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create federated identity credentials client: %w", err)
+		}
+	}
+
+	for _, cr := range crs {
+		providerSpec := &credreqv1.AzureProviderSpec{}
+		if err := credreqv1.Codec.DecodeProviderSpec(cr.Spec.ProviderSpec, providerSpec); err != nil {
+			return fmt.Errorf("failed to decode ProviderSpec for %s/%s: %w", cr.Namespace, cr.Spec.SecretRef.Name, err)
+		}
+
+		name := managedIdentityName(opts.Name, cr)
+
+		var clientID string
+		if opts.DryRun {
+			log.WithField("name", name).Info("dry-run: would create managed identity")
+			clientID = "00000000-0000-0000-0000-000000000000"
+		} else {
+			identity, err := createManagedIdentity(msiClient, opts.ResourceGroupName, opts.Region, name)
+			if err != nil {
+				return err
+			}
+
+			if err := federateManagedIdentity(fedClient, opts.ResourceGroupName, name, opts.IssuerURL, cr.Spec.ServiceAccountNames, cr.Spec.SecretRef.Namespace); err != nil {
+				return err
+			}
+
+			clientID = *identity.Properties.ClientID
+			log.WithField("name", name).Info("created managed identity")
+		}
+
+		secret := targetSecret(cr, targetSecretData{
+			ClientID:           clientID,
+			TenantID:           opts.TenantID,
+			SubscriptionID:     opts.SubscriptionID,
+			Region:             opts.Region,
+			FederatedTokenFile: federatedTokenFilePath,
+		})
+
+		outDir := fmt.Sprintf("%s/manifests", opts.OutputDir)
+		fileName := fmt.Sprintf("%s-%s-credentials.yaml", cr.Spec.SecretRef.Namespace, cr.Spec.SecretRef.Name)
+		if err := writeManifest(outDir, fileName, secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createManagedIdentity(client *armmsi.UserAssignedIdentitiesClient, resourceGroupName, region, name string) (*armmsi.Identity, error) {
+	resp, err := client.CreateOrUpdate(context.Background(), resourceGroupName, name, armmsi.Identity{
+		Location: to.Ptr(region),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create managed identity %s: %w", name, err)
+	}
+	return &resp.Identity, nil
+}
+
+// federateManagedIdentity binds a FederatedIdentityCredential per
+// ServiceAccount this CredentialsRequest targets, so that a token
+// projected for any of those ServiceAccounts can be exchanged for an
+// Azure AD token scoped to the managed identity.
+func federateManagedIdentity(client *armmsi.FederatedIdentityCredentialsClient, resourceGroupName, identityName, issuerURL string, serviceAccountNames []string, namespace string) error {
+	for _, saName := range serviceAccountNames {
+		subject := fmt.Sprintf("system:serviceaccount:%s:%s", namespace, saName)
+		fedName := fmt.Sprintf("%s-%s", identityName, saName)
+
+		_, err := client.CreateOrUpdate(context.Background(), resourceGroupName, identityName, fedName, armmsi.FederatedIdentityCredential{
+			Properties: &armmsi.FederatedIdentityCredentialProperties{
+				Issuer:    to.Ptr(issuerURL),
+				Subject:   to.Ptr(subject),
+				Audiences: []*string{to.Ptr("api://AzureADTokenExchange")},
+			},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create federated identity credential %s: %w", fedName, err)
+		}
+	}
+
+	return nil
+}
+
+func targetSecret(cr *credreqv1.CredentialsRequest, data targetSecretData) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Spec.SecretRef.Name,
+			Namespace: cr.Spec.SecretRef.Namespace,
+		},
+		StringData: map[string]string{
+			"azure_client_id":            data.ClientID,
+			"azure_tenant_id":            data.TenantID,
+			"azure_subscription_id":      data.SubscriptionID,
+			"azure_region":               data.Region,
+			"azure_federated_token_file": data.FederatedTokenFile,
+		},
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// oidcDiscoveryDocument is the minimal OIDC discovery document Azure AD
+// Workload Identity Federation needs to validate tokens signed by our
+// ServiceAccount issuer.
+type oidcDiscoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+var (
+	// CreateOIDCIssuerOpts captures the options for the create-oidc-issuer
+	// command.
+	CreateOIDCIssuerOpts struct {
+		Name              string
+		Region            string
+		SubscriptionID    string
+		ResourceGroupName string
+		OutputDir         string
+		DryRun            bool
+	}
+)
+
+// NewCreateOIDCIssuerCmd publishes the JWKS produced by create-key-pair,
+// together with an OIDC discovery document it generates, to an Azure
+// Storage blob container with anonymous blob-level read access, and logs
+// the resulting issuer URL (https://<account>.blob.core.windows.net/<name>).
+// Static website hosting is not enabled on the storage account.
+func NewCreateOIDCIssuerCmd() *cobra.Command {
+	createOIDCIssuerCmd := &cobra.Command{
+		Use:   "create-oidc-issuer",
+		Short: "Create an OIDC issuer backed by a public Azure Storage blob container",
+		RunE:  createOIDCIssuerCmd,
+	}
+
+	createOIDCIssuerCmd.PersistentFlags().StringVar(&CreateOIDCIssuerOpts.Name, "name", "", "User-defined name for all created Azure resources (can be separate from the cluster's infra-id)")
+	createOIDCIssuerCmd.MarkPersistentFlagRequired("name")
+	createOIDCIssuerCmd.PersistentFlags().StringVar(&CreateOIDCIssuerOpts.Region, "region", "", "Azure region in which to create the storage account")
+	createOIDCIssuerCmd.MarkPersistentFlagRequired("region")
+	createOIDCIssuerCmd.PersistentFlags().StringVar(&CreateOIDCIssuerOpts.SubscriptionID, "subscription-id", "", "Azure subscription ID to create resources in")
+	createOIDCIssuerCmd.MarkPersistentFlagRequired("subscription-id")
+	createOIDCIssuerCmd.PersistentFlags().StringVar(&CreateOIDCIssuerOpts.ResourceGroupName, "resource-group-name", "", "Name of an existing Azure resource group to create the storage account in")
+	createOIDCIssuerCmd.MarkPersistentFlagRequired("resource-group-name")
+	createOIDCIssuerCmd.PersistentFlags().StringVar(&CreateOIDCIssuerOpts.OutputDir, "output-dir", ".", "Directory containing the serviceaccount-signer key pair, and to place generated manifests in")
+	createOIDCIssuerCmd.PersistentFlags().BoolVar(&CreateOIDCIssuerOpts.DryRun, "dry-run", false, "Skip creating cloud objects, and just save the files to disk")
+
+	return createOIDCIssuerCmd
+}
+
+func createOIDCIssuerCmd(cmd *cobra.Command, args []string) error {
+	issuerURL, err := createOIDCIssuer(
+		CreateOIDCIssuerOpts.Name,
+		CreateOIDCIssuerOpts.Region,
+		CreateOIDCIssuerOpts.SubscriptionID,
+		CreateOIDCIssuerOpts.ResourceGroupName,
+		CreateOIDCIssuerOpts.OutputDir,
+		CreateOIDCIssuerOpts.DryRun,
+	)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("issuerURL", issuerURL).Info("OIDC issuer is ready")
+	return nil
+}
+
+func createOIDCIssuer(name, region, subscriptionID, resourceGroupName, outputDir string, dryRun bool) (string, error) {
+	storageAccountName := storageAccountNameFor(name)
+	keysDir := filepath.Join(outputDir, "serviceaccount-signer")
+
+	jwks, err := ioutil.ReadFile(filepath.Join(keysDir, jwksFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read JWKS, run create-key-pair first: %w", err)
+	}
+
+	if dryRun {
+		issuerURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s", storageAccountName, name)
+		discovery := oidcDiscoveryDocument{
+			Issuer:                           issuerURL,
+			JWKSURI:                          issuerURL + "/" + jwksFileName,
+			ResponseTypesSupported:           []string{"id_token"},
+			SubjectTypesSupported:            []string{"public"},
+			IDTokenSigningAlgValuesSupported: []string{"RS256"},
+		}
+		if err := writeJSONDocument(keysDir, discoveryDocFileName, discovery); err != nil {
+			return "", err
+		}
+		return issuerURL, nil
+	}
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain Azure credential: %w", err)
+	}
+
+	accountsClient, err := armstorage.NewAccountsClient(subscriptionID, cred, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create storage accounts client: %w", err)
+	}
+
+	ctx := context.Background()
+	poller, err := accountsClient.BeginCreate(ctx, resourceGroupName, storageAccountName, armstorage.AccountCreateParameters{
+		Location: to.Ptr(region),
+		SKU:      &armstorage.SKU{Name: to.Ptr(armstorage.SKUNameStandardLRS)},
+		Kind:     to.Ptr(armstorage.KindStorageV2),
+		Properties: &armstorage.AccountPropertiesCreateParameters{
+			AllowBlobPublicAccess: to.Ptr(true),
+		},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create storage account %s: %w", storageAccountName, err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return "", fmt.Errorf("failed waiting for storage account %s: %w", storageAccountName, err)
+	}
+
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", storageAccountName)
+	blobClient, err := azblob.NewClient(serviceURL, cred, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create blob client: %w", err)
+	}
+
+	// The discovery document and JWKS must be anonymously readable so Azure
+	// AD can fetch them when validating tokens; AllowBlobPublicAccess on
+	// the account is necessary but not sufficient, the container itself
+	// must also grant public (blob-level) read access.
+	if _, err := blobClient.CreateContainer(ctx, name, &azblob.CreateContainerOptions{Access: to.Ptr(azblob.PublicAccessTypeBlob)}); err != nil {
+		log.WithError(err).Debug("container may already exist")
+	}
+
+	issuerURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s", storageAccountName, name)
+
+	discovery := oidcDiscoveryDocument{
+		Issuer:                           issuerURL,
+		JWKSURI:                          issuerURL + "/" + jwksFileName,
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+	}
+	if err := writeJSONDocument(keysDir, discoveryDocFileName, discovery); err != nil {
+		return "", err
+	}
+	discoveryDoc, err := ioutil.ReadFile(filepath.Join(keysDir, discoveryDocFileName))
+	if err != nil {
+		return "", err
+	}
+
+	uploads := map[string][]byte{
+		".well-known/openid-configuration": discoveryDoc,
+		jwksFileName:                       jwks,
+	}
+	for blobName, content := range uploads {
+		if _, err := blobClient.UploadBuffer(ctx, name, blobName, content, nil); err != nil {
+			return "", fmt.Errorf("failed to upload %s: %w", blobName, err)
+		}
+	}
+
+	return issuerURL, nil
+}
+
+func storageAccountNameFor(name string) string {
+	// Storage account names must be 3-24 lowercase alphanumeric characters,
+	// but infra/--name values routinely contain hyphens and uppercase, so
+	// strip anything else out before truncating.
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, name)
+
+	if len(name) > 20 {
+		name = name[:20]
+	}
+	return fmt.Sprintf("cco%s", name)
+}
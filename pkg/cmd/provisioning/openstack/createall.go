@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var (
+	// CreateAllOpts captures the options for the create-all command.
+	CreateAllOpts options
+)
+
+// NewCreateAllCmd runs every step needed to provision OpenStack credentials
+// out-of-cluster, equivalent to running create-application-credentials on
+// its own. It mirrors the "create-all" convenience command offered by the
+// AWS and IBM Cloud ccoctl subcommands.
+func NewCreateAllCmd() *cobra.Command {
+	createAllCmd := &cobra.Command{
+		Use:   "create-all",
+		Short: "Create all the required OpenStack resources",
+		RunE:  createAllCmd,
+	}
+
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.Name, "name", "", "User-defined name for all created OpenStack resources (can be separate from the cluster's infra-id)")
+	createAllCmd.MarkPersistentFlagRequired("name")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.CloudsYAMLPath, "clouds-yaml", "", "Path to a clouds.yaml containing the root credential used to create Application Credentials")
+	createAllCmd.MarkPersistentFlagRequired("clouds-yaml")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.CloudName, "cloud", "openstack", "Name of the cloud entry to use out of clouds-yaml")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.CredRequestDir, "credentials-requests-dir", "", "Directory containing files of CredentialsRequests to create Application Credentials for")
+	createAllCmd.MarkPersistentFlagRequired("credentials-requests-dir")
+	createAllCmd.PersistentFlags().StringVar(&CreateAllOpts.OutputDir, "output-dir", ".", "Directory to place generated manifests in")
+	createAllCmd.PersistentFlags().BoolVar(&CreateAllOpts.DryRun, "dry-run", false, "Skip creating objects, and just save the files to disk")
+
+	return createAllCmd
+}
+
+func createAllCmd(cmd *cobra.Command, args []string) error {
+	CreateApplicationCredentialsOpts = CreateAllOpts
+	return createApplicationCredentialsCmd(cmd, args)
+}
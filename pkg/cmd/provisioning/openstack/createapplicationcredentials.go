@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+var (
+	// CreateApplicationCredentialsOpts captures the options for the
+	// create-application-credentials command.
+	CreateApplicationCredentialsOpts options
+)
+
+type options struct {
+	Name           string
+	CloudsYAMLPath string
+	CloudName      string
+	CredRequestDir string
+	OutputDir      string
+	DryRun         bool
+}
+
+// NewCreateApplicationCredentialsCmd provisions a Keystone Application
+// Credential per CredentialsRequest found in CredRequestDir, and writes a
+// target-namespace clouds.yaml Secret manifest to OutputDir authenticating
+// via that Application Credential. Nothing is applied to the cluster.
+func NewCreateApplicationCredentialsCmd() *cobra.Command {
+	createApplicationCredentialsCmd := &cobra.Command{
+		Use:   "create-application-credentials",
+		Short: "Create Keystone Application Credentials for each CredentialsRequest",
+		RunE:  createApplicationCredentialsCmd,
+	}
+
+	createApplicationCredentialsCmd.PersistentFlags().StringVar(&CreateApplicationCredentialsOpts.Name, "name", "", "User-defined name for all created OpenStack resources (can be separate from the cluster's infra-id)")
+	createApplicationCredentialsCmd.MarkPersistentFlagRequired("name")
+	createApplicationCredentialsCmd.PersistentFlags().StringVar(&CreateApplicationCredentialsOpts.CloudsYAMLPath, "clouds-yaml", "", "Path to a clouds.yaml containing the root credential used to create Application Credentials")
+	createApplicationCredentialsCmd.MarkPersistentFlagRequired("clouds-yaml")
+	createApplicationCredentialsCmd.PersistentFlags().StringVar(&CreateApplicationCredentialsOpts.CloudName, "cloud", "openstack", "Name of the cloud entry to use out of clouds-yaml")
+	createApplicationCredentialsCmd.PersistentFlags().StringVar(&CreateApplicationCredentialsOpts.CredRequestDir, "credentials-requests-dir", "", "Directory containing files of CredentialsRequests to create Application Credentials for")
+	createApplicationCredentialsCmd.MarkPersistentFlagRequired("credentials-requests-dir")
+	createApplicationCredentialsCmd.PersistentFlags().StringVar(&CreateApplicationCredentialsOpts.OutputDir, "output-dir", ".", "Directory to place generated manifests in")
+	createApplicationCredentialsCmd.PersistentFlags().BoolVar(&CreateApplicationCredentialsOpts.DryRun, "dry-run", false, "Skip creating objects, and just save the files to disk")
+
+	return createApplicationCredentialsCmd
+}
+
+// newIdentityClient authenticates against Keystone using the root credential
+// in clouds.yaml and returns an Identity v3 client scoped to the project
+// the root credential already belongs to.
+func newIdentityClient(rootCloud *cloudEntry) (*gophercloud.ServiceClient, error) {
+	authOpts := gophercloud.AuthOptions{
+		IdentityEndpoint: rootCloud.Auth.AuthURL,
+		Username:         rootCloud.Auth.Username,
+		Password:         rootCloud.Auth.Password,
+		TenantName:       rootCloud.Auth.ProjectName,
+		DomainName:       rootCloud.Auth.UserDomainName,
+		AllowReauth:      true,
+		Scope: &gophercloud.AuthScope{
+			ProjectName: rootCloud.Auth.ProjectName,
+			DomainName:  rootCloud.Auth.ProjectDomainName,
+		},
+	}
+
+	provider, err := openstack.AuthenticatedClient(authOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate to %s: %w", rootCloud.Auth.AuthURL, err)
+	}
+
+	return openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{Region: rootCloud.RegionName})
+}
+
+// createApplicationCredential creates (or re-creates) a single Application
+// Credential scoped to the requesting user, returning its id and secret.
+// Application Credentials cannot be re-read once created, so if one
+// already exists under this name it is deleted first.
+func createApplicationCredential(identityClient *gophercloud.ServiceClient, userID, name string, roles []credreqv1.OpenStackRoleRef) (*applicationcredentials.ApplicationCredential, error) {
+	if existing, err := findApplicationCredentialByName(identityClient, userID, name); err == nil && existing != nil {
+		if err := applicationcredentials.Delete(identityClient, userID, existing.ID).ExtractErr(); err != nil {
+			return nil, fmt.Errorf("failed to delete existing application credential %s: %w", name, err)
+		}
+	}
+
+	createOpts := applicationcredentials.CreateOpts{
+		Name:        name,
+		Description: "Created by ccoctl for an OpenShift CredentialsRequest",
+	}
+
+	for _, role := range roles {
+		createOpts.Roles = append(createOpts.Roles, applicationcredentials.Role{Name: role.Name})
+	}
+
+	cred, err := applicationcredentials.Create(identityClient, userID, createOpts).Extract()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create application credential %s: %w", name, err)
+	}
+
+	return cred, nil
+}
+
+func findApplicationCredentialByName(identityClient *gophercloud.ServiceClient, userID, name string) (*applicationcredentials.ApplicationCredential, error) {
+	pages, err := applicationcredentials.List(identityClient, userID, applicationcredentials.ListOpts{Name: name}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := applicationcredentials.ExtractApplicationCredentials(pages)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range creds {
+		if creds[i].Name == name {
+			return &creds[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func currentUserID(identityClient *gophercloud.ServiceClient, tokenID string) (string, error) {
+	result := tokens.Get(identityClient, tokenID)
+	user, err := result.ExtractUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine current user: %w", err)
+	}
+	return user.ID, nil
+}
+
+func createApplicationCredentialsCmd(cmd *cobra.Command, args []string) error {
+	rootCloud, err := loadCloudFromFile(CreateApplicationCredentialsOpts.CloudsYAMLPath, CreateApplicationCredentialsOpts.CloudName)
+	if err != nil {
+		return err
+	}
+
+	crs, err := getListOfCredentialsRequests(CreateApplicationCredentialsOpts.CredRequestDir)
+	if err != nil {
+		return err
+	}
+
+	var identityClient *gophercloud.ServiceClient
+	var userID string
+	if !CreateApplicationCredentialsOpts.DryRun {
+		identityClient, err = newIdentityClient(rootCloud)
+		if err != nil {
+			return err
+		}
+		userID, err = currentUserID(identityClient, identityClient.ProviderClient.TokenID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, cr := range crs {
+		providerSpec := &credreqv1.OpenStackProviderSpec{}
+		if err := credreqv1.Codec.DecodeProviderSpec(cr.Spec.ProviderSpec, providerSpec); err != nil {
+			return fmt.Errorf("failed to decode ProviderSpec for %s/%s: %w", cr.Namespace, cr.Spec.SecretRef.Name, err)
+		}
+
+		name := credentialsRequestName(CreateApplicationCredentialsOpts.Name, cr)
+
+		cloud := cloudEntry{
+			RegionName:         rootCloud.RegionName,
+			IdentityAPIVersion: rootCloud.IdentityAPIVersion,
+			CACert:             rootCloud.CACert,
+			Verify:             rootCloud.Verify,
+			Auth: cloudAuth{
+				AuthURL:  rootCloud.Auth.AuthURL,
+				AuthType: "v3applicationcredential",
+			},
+		}
+
+		if CreateApplicationCredentialsOpts.DryRun {
+			log.WithField("name", name).Info("dry-run: would create application credential")
+			cloud.Auth.ApplicationCredentialID = "DUMMY_ID"
+			cloud.Auth.ApplicationCredentialSecret = "DUMMY_SECRET"
+		} else {
+			cred, err := createApplicationCredential(identityClient, userID, name, providerSpec.Roles)
+			if err != nil {
+				return err
+			}
+			cloud.Auth.ApplicationCredentialID = cred.ID
+			cloud.Auth.ApplicationCredentialSecret = cred.Secret
+			log.WithField("name", name).Info("created application credential")
+		}
+
+		outDir := fmt.Sprintf("%s/manifests", CreateApplicationCredentialsOpts.OutputDir)
+		fileName := fmt.Sprintf("%s-%s-credentials.yaml", cr.Spec.SecretRef.Namespace, cr.Spec.SecretRef.Name)
+		if err := writeCloudsYAML(outDir, fileName, cr, cloud); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// DeleteApplicationCredentialsOpts captures the options for the
+	// delete-application-credentials command.
+	DeleteApplicationCredentialsOpts options
+)
+
+// NewDeleteApplicationCredentialsCmd deletes every Application Credential
+// previously created by "ccoctl openstack create-application-credentials"
+// for the given --name.
+func NewDeleteApplicationCredentialsCmd() *cobra.Command {
+	deleteApplicationCredentialsCmd := &cobra.Command{
+		Use:   "delete-application-credentials",
+		Short: "Delete Keystone Application Credentials created by create-application-credentials",
+		RunE:  deleteApplicationCredentialsCmd,
+	}
+
+	deleteApplicationCredentialsCmd.PersistentFlags().StringVar(&DeleteApplicationCredentialsOpts.Name, "name", "", "User-defined name used when the Application Credentials were created")
+	deleteApplicationCredentialsCmd.MarkPersistentFlagRequired("name")
+	deleteApplicationCredentialsCmd.PersistentFlags().StringVar(&DeleteApplicationCredentialsOpts.CloudsYAMLPath, "clouds-yaml", "", "Path to a clouds.yaml containing the root credential used to delete Application Credentials")
+	deleteApplicationCredentialsCmd.MarkPersistentFlagRequired("clouds-yaml")
+	deleteApplicationCredentialsCmd.PersistentFlags().StringVar(&DeleteApplicationCredentialsOpts.CloudName, "cloud", "openstack", "Name of the cloud entry to use out of clouds-yaml")
+	deleteApplicationCredentialsCmd.PersistentFlags().StringVar(&DeleteApplicationCredentialsOpts.CredRequestDir, "credentials-requests-dir", "", "Directory containing files of CredentialsRequests to delete Application Credentials for")
+	deleteApplicationCredentialsCmd.MarkPersistentFlagRequired("credentials-requests-dir")
+
+	return deleteApplicationCredentialsCmd
+}
+
+func deleteApplicationCredentialsCmd(cmd *cobra.Command, args []string) error {
+	rootCloud, err := loadCloudFromFile(DeleteApplicationCredentialsOpts.CloudsYAMLPath, DeleteApplicationCredentialsOpts.CloudName)
+	if err != nil {
+		return err
+	}
+
+	crs, err := getListOfCredentialsRequests(DeleteApplicationCredentialsOpts.CredRequestDir)
+	if err != nil {
+		return err
+	}
+
+	identityClient, err := newIdentityClient(rootCloud)
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(identityClient, identityClient.ProviderClient.TokenID)
+	if err != nil {
+		return err
+	}
+
+	for _, cr := range crs {
+		name := credentialsRequestName(DeleteApplicationCredentialsOpts.Name, cr)
+
+		existing, err := findApplicationCredentialByName(identityClient, userID, name)
+		if err != nil {
+			return fmt.Errorf("failed to look up application credential %s: %w", name, err)
+		}
+		if existing == nil {
+			log.WithField("name", name).Debug("no application credential found, skipping")
+			continue
+		}
+
+		if err := applicationcredentials.Delete(identityClient, userID, existing.ID).ExtractErr(); err != nil {
+			return fmt.Errorf("failed to delete application credential %s: %w", name, err)
+		}
+
+		log.WithField("name", name).Info("deleted application credential")
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openstack implements the `ccoctl openstack` command tree, which
+// provisions least-privilege Keystone Application Credentials for each
+// CredentialsRequest out-of-cluster, mirroring the AWS STS and IBM Cloud
+// ccoctl flows.
+package openstack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+const (
+	// applicationCredentialNamePrefix is prepended to every Application
+	// Credential this tool creates so they can be identified (and cleaned
+	// up) later.
+	applicationCredentialNamePrefix = "openshift"
+)
+
+// NewOpenStackCmd implements the "ccoctl openstack" subcommand.
+func NewOpenStackCmd() *cobra.Command {
+	openstackCmd := &cobra.Command{
+		Use:   "openstack",
+		Short: "Manage credentials objects for OpenStack",
+		Long:  "Creates/updates/deletes Keystone Application Credentials for OpenShift CredentialsRequests",
+	}
+
+	openstackCmd.AddCommand(NewCreateApplicationCredentialsCmd())
+	openstackCmd.AddCommand(NewDeleteApplicationCredentialsCmd())
+	openstackCmd.AddCommand(NewCreateAllCmd())
+
+	return openstackCmd
+}
+
+// clientCloud is the minimal subset of clouds.yaml this tool cares about,
+// sufficient to authenticate with the root credentials and to rewrite a
+// per-CredentialsRequest clouds.yaml that authenticates via an Application
+// Credential instead.
+type clientCloud struct {
+	Clouds map[string]cloudEntry `yaml:"clouds"`
+}
+
+type cloudEntry struct {
+	Auth               cloudAuth `yaml:"auth"`
+	RegionName         string    `yaml:"region_name,omitempty"`
+	IdentityAPIVersion string    `yaml:"identity_api_version,omitempty"`
+	CACert             string    `yaml:"cacert,omitempty"`
+	Verify             *bool     `yaml:"verify,omitempty"`
+}
+
+type cloudAuth struct {
+	AuthURL                     string `yaml:"auth_url,omitempty"`
+	Username                    string `yaml:"username,omitempty"`
+	Password                    string `yaml:"password,omitempty"`
+	ProjectName                 string `yaml:"project_name,omitempty"`
+	ProjectDomainName           string `yaml:"project_domain_name,omitempty"`
+	UserDomainName              string `yaml:"user_domain_name,omitempty"`
+	ApplicationCredentialID     string `yaml:"application_credential_id,omitempty"`
+	ApplicationCredentialSecret string `yaml:"application_credential_secret,omitempty"`
+	AuthType                    string `yaml:"auth_type,omitempty"`
+}
+
+// loadCloudFromFile parses the named cloud out of a clouds.yaml on disk.
+func loadCloudFromFile(cloudsYAMLPath, cloudName string) (*cloudEntry, error) {
+	data, err := ioutil.ReadFile(cloudsYAMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", cloudsYAMLPath, err)
+	}
+
+	clouds := &clientCloud{}
+	if err := yamlv3.Unmarshal(data, clouds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", cloudsYAMLPath, err)
+	}
+
+	cloud, ok := clouds.Clouds[cloudName]
+	if !ok {
+		return nil, fmt.Errorf("cloud %q not found in %s", cloudName, cloudsYAMLPath)
+	}
+
+	return &cloud, nil
+}
+
+// credentialsRequestName derives the Application Credential name for a
+// given CredentialsRequest, namespacing it so multiple clusters sharing a
+// project don't collide.
+func credentialsRequestName(infraName string, cr *credreqv1.CredentialsRequest) string {
+	return fmt.Sprintf("%s-%s-%s", applicationCredentialNamePrefix, infraName, cr.Spec.SecretRef.Name)
+}
+
+// getListOfCredentialsRequests reads every manifest in credRequestDir and
+// returns the CredentialsRequests found in it, skipping any manifest that
+// isn't a CredentialsRequest (the directory is shared with other manifest
+// kinds during a standard OpenShift install).
+func getListOfCredentialsRequests(credRequestDir string) ([]*credreqv1.CredentialsRequest, error) {
+	crs := []*credreqv1.CredentialsRequest{}
+
+	files, err := ioutil.ReadDir(credRequestDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", credRequestDir, err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(credRequestDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", file.Name(), err)
+		}
+
+		cr := &credreqv1.CredentialsRequest{}
+		if err := yaml.Unmarshal(data, cr); err != nil {
+			log.WithField("file", file.Name()).Debug("skipping non-CredentialsRequest manifest")
+			continue
+		}
+
+		if cr.Kind != "CredentialsRequest" {
+			continue
+		}
+
+		if cr.Spec.ProviderSpec == nil {
+			continue
+		}
+
+		crs = append(crs, cr)
+	}
+
+	return crs, nil
+}
+
+// writeCloudsYAML renders a clouds.yaml authenticating via the given
+// Application Credential, wraps it in a Secret manifest targeting cr's
+// SecretRef, and writes it to outputDir/fileName so it can be oc apply'd
+// directly, matching the AWS and Azure ccoctl output conventions.
+func writeCloudsYAML(outputDir, fileName string, cr *credreqv1.CredentialsRequest, cloud cloudEntry) error {
+	clouds := clientCloud{Clouds: map[string]cloudEntry{"openstack": cloud}}
+
+	cloudsYAML, err := yamlv3.Marshal(clouds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal clouds.yaml: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Spec.SecretRef.Name,
+			Namespace: cr.Spec.SecretRef.Namespace,
+		},
+		StringData: map[string]string{
+			"clouds.yaml": string(cloudsYAML),
+		},
+	}
+
+	data, err := yaml.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", outputDir, err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(outputDir, fileName), data, 0600)
+}
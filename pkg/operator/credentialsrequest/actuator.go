@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentialsrequest dispatches each CredentialsRequest to the
+// actuator for the cluster's cloud platform.
+package credentialsrequest
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	openstackactuator "github.com/openshift/cloud-credential-operator/pkg/operator/credentialsrequest/openstack"
+)
+
+// Actuator mints, rotates and revokes the cloud credential backing a single
+// CredentialsRequest. Each supported platform implements it independently.
+type Actuator interface {
+	Create(ctx context.Context, cr *credreqv1.CredentialsRequest) error
+	Update(ctx context.Context, cr *credreqv1.CredentialsRequest) error
+	Delete(ctx context.Context, cr *credreqv1.CredentialsRequest) error
+}
+
+// ActuatorForPlatform returns the Actuator responsible for CredentialsRequests
+// on the given platform.
+func ActuatorForPlatform(platformType configv1.PlatformType, c client.Client) (Actuator, error) {
+	switch platformType {
+	case configv1.OpenStackPlatformType:
+		return openstackactuator.NewActuator(c), nil
+	default:
+		return nil, fmt.Errorf("no credentials request actuator for platform %q", platformType)
+	}
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openstack implements the Mint-mode actuator for OpenStack: it
+// creates one Keystone Application Credential per CredentialsRequest,
+// scoped to the roles the request asks for, and reconciles it into the
+// target namespace as a clouds.yaml Secret.
+package openstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+const (
+	rootSecretName      = "openstack-credentials"
+	rootSecretNamespace = "kube-system"
+
+	// applicationCredentialNamePrefix is prepended to every Application
+	// Credential this actuator creates so they can be identified (and
+	// cleaned up) independently of any that an administrator created by
+	// hand.
+	applicationCredentialNamePrefix = "cco"
+)
+
+// Actuator mints a Keystone Application Credential per CredentialsRequest
+// and reconciles the resulting clouds.yaml into the request's target
+// Secret.
+type Actuator struct {
+	Client client.Client
+}
+
+// NewActuator returns an Actuator wired to the passed-in client.
+func NewActuator(c client.Client) *Actuator {
+	return &Actuator{Client: c}
+}
+
+func (a *Actuator) applicationCredentialName(cr *credreqv1.CredentialsRequest) string {
+	return fmt.Sprintf("%s-%s-%s", applicationCredentialNamePrefix, cr.Namespace, cr.Spec.SecretRef.Name)
+}
+
+// Create mints a new Application Credential for cr and writes the target
+// Secret. It is also used to handle updates: re-running Create rotates the
+// credential, since Application Credentials can't be read back once
+// created.
+func (a *Actuator) Create(ctx context.Context, cr *credreqv1.CredentialsRequest) error {
+	return a.sync(ctx, cr)
+}
+
+// Update re-mints the Application Credential so that changes to the
+// requested roles take effect.
+func (a *Actuator) Update(ctx context.Context, cr *credreqv1.CredentialsRequest) error {
+	return a.sync(ctx, cr)
+}
+
+// Delete revokes the Application Credential backing cr. It does not delete
+// the target Secret.
+func (a *Actuator) Delete(ctx context.Context, cr *credreqv1.CredentialsRequest) error {
+	identityClient, userID, err := a.identityClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := a.applicationCredentialName(cr)
+	existing, err := findApplicationCredentialByName(identityClient, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to look up application credential %s: %w", name, err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	if err := identityClient.Delete(userID, existing.ID); err != nil {
+		return fmt.Errorf("failed to delete application credential %s: %w", name, err)
+	}
+
+	log.WithField("name", name).Info("revoked application credential")
+	return nil
+}
+
+func (a *Actuator) sync(ctx context.Context, cr *credreqv1.CredentialsRequest) error {
+	providerSpec := &credreqv1.OpenStackProviderSpec{}
+	if err := credreqv1.Codec.DecodeProviderSpec(cr.Spec.ProviderSpec, providerSpec); err != nil {
+		return fmt.Errorf("failed to decode ProviderSpec for %s/%s: %w", cr.Namespace, cr.Spec.SecretRef.Name, err)
+	}
+
+	identityClient, userID, err := a.identityClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	name := a.applicationCredentialName(cr)
+
+	if existing, err := findApplicationCredentialByName(identityClient, userID, name); err == nil && existing != nil {
+		// Application Credentials cannot be re-read once created, so
+		// rotation means deleting the old one and minting a fresh one.
+		if err := identityClient.Delete(userID, existing.ID); err != nil {
+			return fmt.Errorf("failed to delete existing application credential %s: %w", name, err)
+		}
+	}
+
+	createOpts := applicationcredentials.CreateOpts{
+		Name:        name,
+		Description: fmt.Sprintf("Mint-mode credential for %s/%s", cr.Namespace, cr.Spec.SecretRef.Name),
+	}
+	for _, role := range providerSpec.Roles {
+		createOpts.Roles = append(createOpts.Roles, applicationcredentials.Role{Name: role.Name})
+	}
+
+	cred, err := identityClient.Create(userID, createOpts)
+	if err != nil {
+		return fmt.Errorf("failed to create application credential %s: %w", name, err)
+	}
+
+	rootCloud, err := a.rootCloud(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.reconcileTargetSecret(ctx, cr, rootCloud, cred)
+}
+
+func (a *Actuator) rootCloud(ctx context.Context) (*rootCloudEntry, error) {
+	secret := &corev1.Secret{}
+	if err := a.Client.Get(ctx, types.NamespacedName{Name: rootSecretName, Namespace: rootSecretNamespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to fetch root secret: %w", err)
+	}
+
+	doc := struct {
+		Clouds map[string]rootCloudEntry `yaml:"clouds"`
+	}{}
+	if err := yaml.Unmarshal(secret.Data["clouds.yaml"], &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse root clouds.yaml: %w", err)
+	}
+
+	cloud, ok := doc.Clouds["openstack"]
+	if !ok {
+		return nil, fmt.Errorf("root clouds.yaml does not contain an \"openstack\" cloud entry")
+	}
+
+	return &cloud, nil
+}
+
+type rootCloudEntry struct {
+	Auth               map[string]string `yaml:"auth"`
+	RegionName         string            `yaml:"region_name,omitempty"`
+	IdentityAPIVersion string            `yaml:"identity_api_version,omitempty"`
+	CACert             string            `yaml:"cacert,omitempty"`
+}
+
+// applicationCredentialClient is the subset of Keystone's Application
+// Credential API this actuator needs. It exists so tests can substitute a
+// fake in place of a real gophercloud-backed client, the same pattern used
+// by secretannotator/openstack's keystoneCapabilityProber.
+type applicationCredentialClient interface {
+	List(userID, name string) ([]applicationcredentials.ApplicationCredential, error)
+	Create(userID string, opts applicationcredentials.CreateOpts) (*applicationcredentials.ApplicationCredential, error)
+	Delete(userID, id string) error
+}
+
+type gophercloudApplicationCredentialClient struct {
+	identityClient *gophercloud.ServiceClient
+}
+
+func (c *gophercloudApplicationCredentialClient) List(userID, name string) ([]applicationcredentials.ApplicationCredential, error) {
+	pages, err := applicationcredentials.List(c.identityClient, userID, applicationcredentials.ListOpts{Name: name}).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return applicationcredentials.ExtractApplicationCredentials(pages)
+}
+
+func (c *gophercloudApplicationCredentialClient) Create(userID string, opts applicationcredentials.CreateOpts) (*applicationcredentials.ApplicationCredential, error) {
+	return applicationcredentials.Create(c.identityClient, userID, opts).Extract()
+}
+
+func (c *gophercloudApplicationCredentialClient) Delete(userID, id string) error {
+	return applicationcredentials.Delete(c.identityClient, userID, id).ExtractErr()
+}
+
+// newIdentityClient builds the real gophercloud-backed
+// applicationCredentialClient. It is a package-level var so tests can
+// substitute a fake and exercise Create/Update/Delete without talking to a
+// real Keystone.
+var newIdentityClient = func(rootCloud *rootCloudEntry) (applicationCredentialClient, string, error) {
+	authOpts := gophercloud.AuthOptions{
+		IdentityEndpoint: rootCloud.Auth["auth_url"],
+		Username:         rootCloud.Auth["username"],
+		Password:         rootCloud.Auth["password"],
+		TenantName:       rootCloud.Auth["project_name"],
+		DomainName:       rootCloud.Auth["user_domain_name"],
+		AllowReauth:      true,
+		Scope: &gophercloud.AuthScope{
+			ProjectName: rootCloud.Auth["project_name"],
+			DomainName:  rootCloud.Auth["project_domain_name"],
+		},
+	}
+
+	provider, err := openstack.AuthenticatedClient(authOpts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to authenticate to %s: %w", authOpts.IdentityEndpoint, err)
+	}
+
+	identityClient, err := openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{Region: rootCloud.RegionName})
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := tokens.Get(identityClient, provider.TokenID).ExtractUser()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to determine current user: %w", err)
+	}
+
+	return &gophercloudApplicationCredentialClient{identityClient: identityClient}, user.ID, nil
+}
+
+func (a *Actuator) identityClient(ctx context.Context) (applicationCredentialClient, string, error) {
+	rootCloud, err := a.rootCloud(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return newIdentityClient(rootCloud)
+}
+
+func findApplicationCredentialByName(identityClient applicationCredentialClient, userID, name string) (*applicationcredentials.ApplicationCredential, error) {
+	creds, err := identityClient.List(userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range creds {
+		if creds[i].Name == name {
+			return &creds[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// reconcileTargetSecret writes (or updates) the CredentialsRequest's
+// target Secret with a clouds.yaml that authenticates via the freshly
+// minted Application Credential instead of the root user/password.
+func (a *Actuator) reconcileTargetSecret(ctx context.Context, cr *credreqv1.CredentialsRequest, rootCloud *rootCloudEntry, cred *applicationcredentials.ApplicationCredential) error {
+	cloudsYAML, err := yaml.Marshal(struct {
+		Clouds map[string]interface{} `yaml:"clouds"`
+	}{
+		Clouds: map[string]interface{}{
+			"openstack": map[string]interface{}{
+				"auth": map[string]string{
+					"auth_url":                      rootCloud.Auth["auth_url"],
+					"application_credential_id":     cred.ID,
+					"application_credential_secret": cred.Secret,
+				},
+				"region_name":          rootCloud.RegionName,
+				"identity_api_version": rootCloud.IdentityAPIVersion,
+				"cacert":               rootCloud.CACert,
+				"auth_type":            "v3applicationcredential",
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal clouds.yaml: %w", err)
+	}
+
+	secret := &corev1.Secret{}
+	err = a.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.SecretRef.Name, Namespace: cr.Spec.SecretRef.Namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cr.Spec.SecretRef.Name,
+				Namespace: cr.Spec.SecretRef.Namespace,
+			},
+			Data: map[string][]byte{"clouds.yaml": cloudsYAML},
+		}
+		return a.Client.Create(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch target secret: %w", err)
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data["clouds.yaml"] = cloudsYAML
+
+	return a.Client.Update(ctx, secret)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+	schemeutils "github.com/openshift/cloud-credential-operator/pkg/util"
+)
+
+// fakeApplicationCredentialClient is an in-memory stand-in for
+// gophercloudApplicationCredentialClient, letting tests drive Create,
+// Update and Delete without talking to a real Keystone.
+type fakeApplicationCredentialClient struct {
+	byName map[string]*applicationcredentials.ApplicationCredential
+	nextID int
+}
+
+func newFakeApplicationCredentialClient() *fakeApplicationCredentialClient {
+	return &fakeApplicationCredentialClient{byName: map[string]*applicationcredentials.ApplicationCredential{}}
+}
+
+func (c *fakeApplicationCredentialClient) List(userID, name string) ([]applicationcredentials.ApplicationCredential, error) {
+	cred, ok := c.byName[name]
+	if !ok {
+		return nil, nil
+	}
+	return []applicationcredentials.ApplicationCredential{*cred}, nil
+}
+
+func (c *fakeApplicationCredentialClient) Create(userID string, opts applicationcredentials.CreateOpts) (*applicationcredentials.ApplicationCredential, error) {
+	c.nextID++
+	cred := &applicationcredentials.ApplicationCredential{
+		ID:     fmt.Sprintf("cred-%d", c.nextID),
+		Name:   opts.Name,
+		Secret: "generated-secret",
+	}
+	c.byName[opts.Name] = cred
+	return cred, nil
+}
+
+func (c *fakeApplicationCredentialClient) Delete(userID, id string) error {
+	for name, cred := range c.byName {
+		if cred.ID == id {
+			delete(c.byName, name)
+			return nil
+		}
+	}
+	return nil
+}
+
+func testCredentialsRequest() *credreqv1.CredentialsRequest {
+	return &credreqv1.CredentialsRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cr",
+			Namespace: "openshift-cloud-credential-operator",
+		},
+		Spec: credreqv1.CredentialsRequestSpec{
+			SecretRef: corev1.ObjectReference{
+				Name:      "target-secret",
+				Namespace: "target-namespace",
+			},
+			ProviderSpec: &runtime.RawExtension{
+				Object: &credreqv1.OpenStackProviderSpec{
+					Roles: []credreqv1.OpenStackRoleRef{{Name: "member"}},
+				},
+			},
+		},
+	}
+}
+
+func testRootSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rootSecretName,
+			Namespace: rootSecretNamespace,
+		},
+		Data: map[string][]byte{
+			"clouds.yaml": []byte(`
+clouds:
+  openstack:
+    auth:
+      auth_url: http://1.2.3.4:5000
+      username: openshift
+      password: password
+      project_name: openshift
+      project_domain_name: Default
+      user_domain_name: Default
+    region_name: regionOne
+    identity_api_version: "3"
+`),
+		},
+	}
+}
+
+func TestActuator_CreateUpdateDelete(t *testing.T) {
+	schemeutils.SetupScheme(scheme.Scheme)
+
+	fakeIdentity := newFakeApplicationCredentialClient()
+	origNewIdentityClient := newIdentityClient
+	newIdentityClient = func(rootCloud *rootCloudEntry) (applicationCredentialClient, string, error) {
+		return fakeIdentity, "test-user-id", nil
+	}
+	defer func() { newIdentityClient = origNewIdentityClient }()
+
+	cr := testCredentialsRequest()
+	fakeClient := fake.NewFakeClient(testRootSecret())
+	a := NewActuator(fakeClient)
+
+	require.NoError(t, a.Create(context.TODO(), cr))
+	assert.Len(t, fakeIdentity.byName, 1, "expected one application credential to be minted")
+
+	targetSecret := &corev1.Secret{}
+	require.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Name: "target-secret", Namespace: "target-namespace"}, targetSecret))
+	assert.Contains(t, string(targetSecret.Data["clouds.yaml"]), "generated-secret")
+
+	require.NoError(t, a.Update(context.TODO(), cr))
+	assert.Len(t, fakeIdentity.byName, 1, "rotating the credential should not leave the old one behind")
+
+	require.NoError(t, a.Delete(context.TODO(), cr))
+	assert.Len(t, fakeIdentity.byName, 0, "expected the application credential to be revoked")
+}
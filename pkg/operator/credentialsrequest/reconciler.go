@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentialsrequest
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	credreqv1 "github.com/openshift/cloud-credential-operator/pkg/apis/cloudcredential/v1"
+)
+
+const (
+	// ControllerName is used in logging and error reporting for this
+	// reconciler.
+	ControllerName = "credentialsrequest"
+
+	infrastructureConfigName = "cluster"
+
+	// finalizerName is set on every CredentialsRequest this controller
+	// mints a credential for, so its actuator gets a chance to revoke that
+	// credential before the CredentialsRequest is actually deleted.
+	finalizerName = "cloudcredential.openshift.io/deprovision"
+)
+
+// ReconcileCredentialsRequest dispatches a CredentialsRequest to the
+// Actuator for the cluster's cloud platform, minting (Create/Update) or
+// revoking (Delete) the credential it describes.
+type ReconcileCredentialsRequest struct {
+	Client client.Client
+	Logger log.FieldLogger
+}
+
+func (r *ReconcileCredentialsRequest) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.WithFields(log.Fields{
+		"controller":         ControllerName,
+		"credentialsRequest": fmt.Sprintf("%s/%s", request.Namespace, request.Name),
+	})
+
+	cr := &credreqv1.CredentialsRequest{}
+	if err := r.Client.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	infra := &configv1.Infrastructure{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: infrastructureConfigName}, infra); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to fetch Infrastructure/%s: %w", infrastructureConfigName, err)
+	}
+
+	actuator, err := ActuatorForPlatform(infra.Status.Platform, r.Client)
+	if err != nil {
+		logger.WithError(err).Debug("no actuator for this platform, nothing to do")
+		return reconcile.Result{}, nil
+	}
+
+	if !cr.DeletionTimestamp.IsZero() {
+		if !hasFinalizer(cr) {
+			return reconcile.Result{}, nil
+		}
+
+		if err := actuator.Delete(context.TODO(), cr); err != nil {
+			logger.WithError(err).Error("failed to revoke credential")
+			return reconcile.Result{}, err
+		}
+
+		removeFinalizer(cr)
+		return reconcile.Result{}, r.Client.Update(context.TODO(), cr)
+	}
+
+	if !hasFinalizer(cr) {
+		cr.Finalizers = append(cr.Finalizers, finalizerName)
+		if err := r.Client.Update(context.TODO(), cr); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if cr.Status.Provisioned {
+		err = actuator.Update(context.TODO(), cr)
+	} else {
+		err = actuator.Create(context.TODO(), cr)
+	}
+	if err != nil {
+		logger.WithError(err).Error("failed to reconcile credential")
+		return reconcile.Result{}, err
+	}
+
+	cr.Status.Provisioned = true
+	return reconcile.Result{}, r.Client.Status().Update(context.TODO(), cr)
+}
+
+func hasFinalizer(cr *credreqv1.CredentialsRequest) bool {
+	for _, f := range cr.Finalizers {
+		if f == finalizerName {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(cr *credreqv1.CredentialsRequest) {
+	finalizers := cr.Finalizers[:0]
+	for _, f := range cr.Finalizers {
+		if f != finalizerName {
+			finalizers = append(finalizers, f)
+		}
+	}
+	cr.Finalizers = finalizers
+}
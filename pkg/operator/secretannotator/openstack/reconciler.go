@@ -0,0 +1,415 @@
+/*
+Copyright 2021 The OpenShift Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/applicationcredentials"
+	"github.com/gophercloud/gophercloud/openstack/identity/v3/tokens"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+
+	"github.com/openshift/cloud-credential-operator/pkg/cloudprovider/detect"
+)
+
+const (
+	// ControllerName is used in logging and error reporting for this
+	// reconciler.
+	ControllerName = "secretannotator-openstack"
+
+	operatorConfigName = "cluster"
+
+	legacyConfigMapNamespace = "openshift-cloud-credential-operator"
+	legacyConfigMapName      = "cloud-credential-operator-config"
+
+	annotationKey = "cloudcredential.openshift.io/mode"
+
+	annotationMint        = "mint"
+	annotationPassthrough = "passthrough"
+
+	// correctCACertPath is where platformCAConfigMapName is projected into
+	// the static pod resources consumed by clouds.yaml. It is the only
+	// path actually materialized on disk, so clouds.yaml's cacert must
+	// always point here, whether or not a user-provided trusted CA has
+	// been merged into the bundle.
+	correctCACertPath = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/ca-bundle.pem"
+
+	// platformCAConfigMapNamespace/Name hold the platform's cloud-provider
+	// CA bundle, the same ConfigMap every in-tree cloud provider reads,
+	// and the one actually projected to correctCACertPath.
+	platformCAConfigMapNamespace = "openshift-config-managed"
+	platformCAConfigMapName      = "kube-cloud-config"
+
+	// trustedCAConfigMapName holds a user-provided CA (e.g. a proxy CA or
+	// a self-signed Keystone endpoint cert), injected by the cluster
+	// network operator into any ConfigMap labeled
+	// config.openshift.io/inject-trusted-cabundle=true.
+	trustedCAConfigMapName = "cloud-credential-operator-trusted-ca"
+
+	caBundleDataKey = "ca-bundle.pem"
+
+	// capabilityProbeNamePrefix identifies the throwaway Application
+	// Credential CanCreateApplicationCredentials creates and deletes to
+	// verify create permission, so it's recognizable if cleanup ever fails
+	// to run.
+	capabilityProbeNamePrefix = "cco-capability-probe"
+)
+
+// ReconcileCloudCredSecret reconciles the OpenStack root credentials
+// Secret: it validates the operator's configured CredentialsMode, marks
+// the secret with the resulting operating mode so downstream actuators
+// know how to behave, and fixes up the clouds.yaml CA cert path.
+type ReconcileCloudCredSecret struct {
+	Client client.Client
+	Logger log.FieldLogger
+}
+
+// cloudYAML is the minimal shape of clouds.yaml this reconciler cares
+// about: the root credential's auth parameters and CA cert path.
+type cloudYAML struct {
+	Clouds map[string]struct {
+		Auth   map[string]string `yaml:"auth"`
+		CACert string            `yaml:"cacert,omitempty"`
+	} `yaml:"clouds"`
+}
+
+func (r *ReconcileCloudCredSecret) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := r.Logger.WithFields(log.Fields{
+		"controller": ControllerName,
+		"secret":     fmt.Sprintf("%s/%s", request.Namespace, request.Name),
+	})
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(context.TODO(), request.NamespacedName, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Debug("root secret not found, nothing to do")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	mode, err := r.determineMode(secret)
+	if err != nil {
+		logger.WithError(err).Error("unable to determine operating mode")
+		return reconcile.Result{}, err
+	}
+
+	if mode != "" {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[annotationKey] = mode
+	}
+
+	if err := r.reconcileCACertPath(secret); err != nil {
+		logger.WithError(err).Error("unable to fix cacert path")
+		return reconcile.Result{}, err
+	}
+
+	if err := r.Client.Update(context.TODO(), secret); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := detect.PublishCapabilities(context.TODO(), r.Client, detect.ProviderCapabilities{
+		// OpenStack has no workload-scoped short-lived credential flow
+		// (Mint mode mints a long-lived Application Credential per
+		// CredentialsRequest, not a federated token), so SupportsSTS is
+		// always false here.
+		SupportsSTS:         false,
+		RootSecretAnnotated: mode != "",
+	}); err != nil {
+		logger.WithError(err).Error("unable to publish capabilities")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// determineMode reads the operator's CloudCredential config, validates it
+// against the legacy disable-CCO config map, and returns the annotation
+// value ("", "passthrough" or "mint") that should be stamped on the root
+// secret.
+func (r *ReconcileCloudCredSecret) determineMode(secret *corev1.Secret) (string, error) {
+	cloudCredConfig := &operatorv1.CloudCredential{}
+	mode := ""
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: operatorConfigName}, cloudCredConfig); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+	} else {
+		mode = string(cloudCredConfig.Spec.CredentialsMode)
+	}
+
+	legacyCM := &corev1.ConfigMap{}
+	legacyErr := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: legacyConfigMapNamespace, Name: legacyConfigMapName}, legacyCM)
+	legacyDisabled := legacyErr == nil && legacyCM.Data["disabled"] == "true"
+
+	if legacyDisabled && mode != "" {
+		return "", fmt.Errorf("legacy %s config map conflicts with explicitly configured CredentialsMode %q", legacyConfigMapName, mode)
+	}
+
+	switch operatorv1.CloudCredentialsMode(mode) {
+	case "":
+		return "", nil
+	case operatorv1.CloudCredentialsModePassthrough:
+		return annotationPassthrough, nil
+	case operatorv1.CloudCredentialsModeMint:
+		capable, err := r.canMintApplicationCredentials(secret)
+		if err != nil {
+			return "", fmt.Errorf("failed to probe Keystone for Application Credential support: %w", err)
+		}
+		if !capable {
+			return "", fmt.Errorf("Mint mode requires a root credential capable of creating Keystone Application Credentials")
+		}
+		return annotationMint, nil
+	default:
+		return "", fmt.Errorf("unsupported CredentialsMode %q for OpenStack", mode)
+	}
+}
+
+// canMintApplicationCredentials probes Keystone, using the root
+// credential found in secret, to confirm it is allowed to create
+// Application Credentials. keystoneClientBuilder is a package-level var
+// so tests can substitute a fake prober.
+func (r *ReconcileCloudCredSecret) canMintApplicationCredentials(secret *corev1.Secret) (bool, error) {
+	prober, err := keystoneClientBuilder(secret)
+	if err != nil {
+		return false, err
+	}
+	return prober.CanCreateApplicationCredentials()
+}
+
+// keystoneCapabilityProber reports whether the root credential reconciled
+// by this controller is capable of creating Keystone Application
+// Credentials, i.e. whether Mint mode can be supported for this cloud.
+type keystoneCapabilityProber interface {
+	CanCreateApplicationCredentials() (bool, error)
+}
+
+type gophercloudCapabilityProber struct {
+	identityClient *gophercloud.ServiceClient
+	userID         string
+}
+
+// CanCreateApplicationCredentials probes the capability Mint mode
+// actually depends on - creating an Application Credential - rather than
+// merely listing them, since a root credential can easily have list-only
+// rights and still fail every real Actuator.Create call. It creates a
+// throwaway, uniquely named Application Credential and deletes it again
+// immediately; a Forbidden response to the create means the root
+// credential isn't allowed to self-manage them, and Mint mode can't be
+// supported.
+func (p *gophercloudCapabilityProber) CanCreateApplicationCredentials() (bool, error) {
+	name := fmt.Sprintf("%s-%d", capabilityProbeNamePrefix, time.Now().UnixNano())
+	cred, err := applicationcredentials.Create(p.identityClient, p.userID, applicationcredentials.CreateOpts{
+		Name:        name,
+		Description: "Temporary probe created by cloud-credential-operator to verify Application Credential create permission; safe to delete.",
+	}).Extract()
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, 403) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := applicationcredentials.Delete(p.identityClient, p.userID, cred.ID).ExtractErr(); err != nil {
+		log.WithError(err).WithField("name", name).Warn("failed to clean up capability probe application credential")
+	}
+
+	return true, nil
+}
+
+var keystoneClientBuilder = func(secret *corev1.Secret) (keystoneCapabilityProber, error) {
+	clouds, err := parseCloudYAML(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	cloud, ok := clouds.Clouds["openstack"]
+	if !ok {
+		return nil, fmt.Errorf("clouds.yaml does not contain an \"openstack\" cloud entry")
+	}
+
+	authOpts := gophercloud.AuthOptions{
+		IdentityEndpoint: cloud.Auth["auth_url"],
+		Username:         cloud.Auth["username"],
+		Password:         cloud.Auth["password"],
+		TenantName:       cloud.Auth["project_name"],
+		DomainName:       cloud.Auth["user_domain_name"],
+		AllowReauth:      true,
+		Scope: &gophercloud.AuthScope{
+			ProjectName: cloud.Auth["project_name"],
+			DomainName:  cloud.Auth["project_domain_name"],
+		},
+	}
+
+	provider, err := openstack.AuthenticatedClient(authOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate to %s: %w", authOpts.IdentityEndpoint, err)
+	}
+
+	identityClient, err := openstack.NewIdentityV3(provider, gophercloud.EndpointOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := tokens.Get(identityClient, provider.TokenID).ExtractUser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine current user: %w", err)
+	}
+
+	return &gophercloudCapabilityProber{identityClient: identityClient, userID: user.ID}, nil
+}
+
+func parseCloudYAML(secret *corev1.Secret) (*cloudYAML, error) {
+	clouds := &cloudYAML{}
+	if err := yaml.Unmarshal(secret.Data["clouds.yaml"], clouds); err != nil {
+		return nil, fmt.Errorf("failed to parse clouds.yaml: %w", err)
+	}
+	return clouds, nil
+}
+
+// reconcileCACertPath rewrites clouds.yaml's cacert field so it points at
+// correctCACertPath, the only bundle path actually projected into the
+// static pod resources, preserving every other field untouched. If the
+// cluster has a user-provided trusted CA injected into
+// trustedCAConfigMapName, it is merged into platformCAConfigMapName
+// itself, in place, so the combined bundle ends up at the same
+// correctCACertPath that already gets projected - there is nowhere else
+// to put it that actually reaches disk. It leaves clouds.yaml alone if no
+// cacert is configured at all.
+func (r *ReconcileCloudCredSecret) reconcileCACertPath(secret *corev1.Secret) error {
+	raw, ok := secret.Data["clouds.yaml"]
+	if !ok {
+		return nil
+	}
+
+	doc := map[string]map[string]map[string]interface{}{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("failed to parse clouds.yaml: %w", err)
+	}
+
+	cloud, ok := doc["clouds"]["openstack"]
+	if !ok {
+		return nil
+	}
+
+	cacert, _ := cloud["cacert"].(string)
+	if cacert == "" {
+		return nil
+	}
+
+	platformCA, err := r.getConfigMapData(platformCAConfigMapNamespace, platformCAConfigMapName)
+	if err != nil {
+		return err
+	}
+
+	trustedCA, err := r.getConfigMapData(legacyConfigMapNamespace, trustedCAConfigMapName)
+	if err != nil {
+		return err
+	}
+	if trustedCA != "" && !validPEMBundle(trustedCA) {
+		return fmt.Errorf("%s config map does not contain a valid %s", trustedCAConfigMapName, caBundleDataKey)
+	}
+
+	if trustedCA != "" {
+		combined := strings.TrimSpace(platformCA + "\n" + trustedCA)
+		if err := r.reconcilePlatformCAConfigMap(combined); err != nil {
+			return err
+		}
+	}
+
+	if cacert == correctCACertPath {
+		return nil
+	}
+
+	cloud["cacert"] = correctCACertPath
+
+	updated, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal clouds.yaml: %w", err)
+	}
+	secret.Data["clouds.yaml"] = updated
+
+	return nil
+}
+
+// getConfigMapData returns the ca-bundle.pem entry of the named
+// ConfigMap, or "" if the ConfigMap (or the key within it) doesn't exist.
+func (r *ReconcileCloudCredSecret) getConfigMapData(namespace, name string) (string, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return cm.Data[caBundleDataKey], nil
+}
+
+// reconcilePlatformCAConfigMap writes the merged CA bundle back into
+// platformCAConfigMapName itself, the only ConfigMap actually projected to
+// correctCACertPath, creating it if it doesn't already exist.
+func (r *ReconcileCloudCredSecret) reconcilePlatformCAConfigMap(combined string) error {
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: platformCAConfigMapNamespace, Name: platformCAConfigMapName}
+	err := r.Client.Get(context.TODO(), key, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      platformCAConfigMapName,
+				Namespace: platformCAConfigMapNamespace,
+			},
+			Data: map[string]string{caBundleDataKey: combined},
+		}
+		return r.Client.Create(context.TODO(), cm)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data[caBundleDataKey] == combined {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[caBundleDataKey] = combined
+	return r.Client.Update(context.TODO(), cm)
+}
+
+// validPEMBundle reports whether data contains at least one parseable PEM
+// block, used to reject a malformed user-provided trusted CA ConfigMap.
+func validPEMBundle(data string) bool {
+	block, _ := pem.Decode([]byte(data))
+	return block != nil
+}
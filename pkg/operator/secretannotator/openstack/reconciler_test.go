@@ -115,8 +115,11 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 		* If an invalid mode is specified we should return an error
 		* If the Passthrough mode is specified explicitly we should annotate the
 		  secret with this mode.
-		* If the Mint mode is specified explicitly we should return an error,
-		  because this is not supported by OpenStack.
+		* If the Mint mode is specified and the root credential can create
+		  Keystone Application Credentials, we should annotate the secret with
+		  this mode.
+		* If the Mint mode is specified but the root credential cannot create
+		  Keystone Application Credentials, we should return an error.
 
 	*/
 	t.Run("Test operating mode", func(t *testing.T) {
@@ -134,6 +137,7 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 			name           string
 			mode           string
 			existing       []runtime.Object
+			canMint        bool
 			wantAnnotation string
 			wantErr        bool
 		}{
@@ -169,11 +173,26 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 				name:           "Mint",
 				mode:           "Mint",
 				existing:       nil,
+				canMint:        true,
+				wantAnnotation: "mint",
+				wantErr:        false,
+			},
+			{
+				name:           "Mint without application credential support",
+				mode:           "Mint",
+				existing:       nil,
+				canMint:        false,
 				wantAnnotation: "",
 				wantErr:        true,
 			},
 		} {
 			t.Run(tc.name, func(t *testing.T) {
+				origKeystoneClientBuilder := keystoneClientBuilder
+				keystoneClientBuilder = func(secret *corev1.Secret) (keystoneCapabilityProber, error) {
+					return fakeKeystoneCapabilityProber{canCreate: tc.canMint}, nil
+				}
+				defer func() { keystoneClientBuilder = origKeystoneClientBuilder }()
+
 				secret := testSecret("")
 				existing := append(tc.existing, infra, secret, testOperatorConfig(tc.mode))
 				fakeClient := fake.NewFakeClient(existing...)
@@ -217,6 +236,11 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 		  should update it
 		* If the root secret clouds.yaml contains the correct CA Cert path we
 		  should not modify it
+		* If a cloud-credential-operator-trusted-ca config map is present with a
+		  valid CA bundle, it should be merged with the platform CA and clouds.yaml
+		  should point at the combined bundle
+		* If the cloud-credential-operator-trusted-ca config map is present but its
+		  ca-bundle.pem is not valid PEM, we should return an error
 
 	*/
 	t.Run("Test fix cacert path", func(t *testing.T) {
@@ -231,12 +255,38 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 		const incorrectCACertFile = "/incorrect/path/to/ca-bundle.pem"
 		const correctCACertFile = "/etc/kubernetes/static-pod-resources/configmaps/cloud-config/ca-bundle.pem"
 
+		platformCAConfigMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kube-cloud-config",
+				Namespace: "openshift-config-managed",
+			},
+			Data: map[string]string{"ca-bundle.pem": "-----BEGIN CERTIFICATE-----\nplatform\n-----END CERTIFICATE-----"},
+		}
+
+		validTrustedCAConfigMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cloud-credential-operator-trusted-ca",
+				Namespace: "openshift-cloud-credential-operator",
+			},
+			Data: map[string]string{"ca-bundle.pem": "-----BEGIN CERTIFICATE-----\nuser\n-----END CERTIFICATE-----"},
+		}
+
+		malformedTrustedCAConfigMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cloud-credential-operator-trusted-ca",
+				Namespace: "openshift-cloud-credential-operator",
+			},
+			Data: map[string]string{"ca-bundle.pem": "not a certificate"},
+		}
+
 		for _, tc := range [...]struct {
-			name           string
-			cacert         string
-			expectedCACert string
-			skipDiff       bool
-			wantErr        bool
+			name               string
+			cacert             string
+			existing           []runtime.Object
+			expectedCACert     string
+			wantMergedCABundle string
+			skipDiff           bool
+			wantErr            bool
 		}{
 			{
 				name:     "invalid YAML",
@@ -262,10 +312,34 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 				expectedCACert: correctCACertFile,
 				wantErr:        false,
 			},
+			{
+				name:               "Merge trusted CA with platform CA",
+				cacert:             correctCACertFile,
+				existing:           []runtime.Object{platformCAConfigMap, validTrustedCAConfigMap},
+				expectedCACert:     correctCACertFile,
+				wantMergedCABundle: "-----BEGIN CERTIFICATE-----\nplatform\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nuser\n-----END CERTIFICATE-----",
+				wantErr:            false,
+			},
+			{
+				name:               "Merge trusted CA absent platform CA",
+				cacert:             correctCACertFile,
+				existing:           []runtime.Object{validTrustedCAConfigMap},
+				expectedCACert:     correctCACertFile,
+				wantMergedCABundle: "-----BEGIN CERTIFICATE-----\nuser\n-----END CERTIFICATE-----",
+				wantErr:            false,
+			},
+			{
+				name:     "Malformed trusted CA",
+				cacert:   correctCACertFile,
+				existing: []runtime.Object{malformedTrustedCAConfigMap},
+				skipDiff: true,
+				wantErr:  true,
+			},
 		} {
 			t.Run(tc.name, func(t *testing.T) {
 				secret := testSecret(tc.cacert)
-				fakeClient := fake.NewFakeClient(infra, passthrough, secret)
+				existing := append(tc.existing, infra, passthrough, secret)
+				fakeClient := fake.NewFakeClient(existing...)
 
 				r := &ReconcileCloudCredSecret{
 					Client: fakeClient,
@@ -300,11 +374,35 @@ func TestReconcileCloudCredSecret_Reconcile(t *testing.T) {
 					require.NoError(t, err, "Unexpected error parsing updated clouds.yaml")
 					assert.Equal(t, origClouds, reconciledClouds, "Secret was not updated as expected")
 				}
+
+				if tc.wantMergedCABundle != "" {
+					// Confirm the merged bundle actually lands at
+					// correctCACertFile's source ConfigMap, not somewhere
+					// nothing projects to disk.
+					cm := &corev1.ConfigMap{}
+					err = fakeClient.Get(context.TODO(), client.ObjectKey{
+						Namespace: platformCAConfigMapNamespace,
+						Name:      platformCAConfigMapName,
+					}, cm)
+					require.NoError(t, err, "Failed to fetch platform CA config map after ReconcileCloudCredSecret.Reconcile()")
+					assert.Equal(t, tc.wantMergedCABundle, cm.Data[caBundleDataKey], "Platform CA config map does not contain the merged bundle")
+				}
 			})
 		}
 	})
 }
 
+// fakeKeystoneCapabilityProber stubs out the real gophercloud-backed
+// prober so "Test operating mode" can exercise Mint mode without talking
+// to a real Keystone.
+type fakeKeystoneCapabilityProber struct {
+	canCreate bool
+}
+
+func (p fakeKeystoneCapabilityProber) CanCreateApplicationCredentials() (bool, error) {
+	return p.canCreate, nil
+}
+
 func testOperatorConfig(mode string) *operatorv1.CloudCredential {
 	return &operatorv1.CloudCredential{
 		ObjectMeta: metav1.ObjectMeta{